@@ -0,0 +1,259 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/nats-io/jwt/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// AccountState is the full desired-state document read by
+// `nsc edit account --from-file` and written by
+// `nsc describe account --export-state`. It mirrors the subset of
+// jwt.AccountClaims that is safe and useful to manage declaratively;
+// identity fields (subject, issuer) are intentionally absent since they
+// are not something a GitOps file should be able to change.
+//
+// start and expiry are written by --export-state, but --from-file does
+// not apply them: the account's start and expiry are left unchanged and
+// never appear in the diff.
+type AccountState struct {
+	Name        string   `json:"name" yaml:"name"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Info        string   `json:"info_url,omitempty" yaml:"info_url,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+
+	SigningKeys []string `json:"signing_keys,omitempty" yaml:"signing_keys,omitempty"`
+
+	DisallowBearer bool `json:"disallow_bearer,omitempty" yaml:"disallow_bearer,omitempty"`
+
+	NotBefore string `json:"start,omitempty" yaml:"start,omitempty"`
+	Expires   string `json:"expiry,omitempty" yaml:"expiry,omitempty"`
+
+	PubAllow []string `json:"pub_allow,omitempty" yaml:"pub_allow,omitempty"`
+	PubDeny  []string `json:"pub_deny,omitempty" yaml:"pub_deny,omitempty"`
+	SubAllow []string `json:"sub_allow,omitempty" yaml:"sub_allow,omitempty"`
+	SubDeny  []string `json:"sub_deny,omitempty" yaml:"sub_deny,omitempty"`
+
+	MaxResponses int    `json:"max_responses,omitempty" yaml:"max_responses,omitempty"`
+	ResponseTTL  string `json:"response_ttl,omitempty" yaml:"response_ttl,omitempty"`
+
+	Limits jwt.OperatorLimits            `json:"limits,omitempty" yaml:"limits,omitempty"`
+	Tiers  map[string]jwt.JetStreamLimits `json:"tiers,omitempty" yaml:"tiers,omitempty"`
+}
+
+func loadAccountState(path string) (*AccountState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s AccountState
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("error parsing %q: %v", path, err)
+	}
+	return &s, nil
+}
+
+// exportAccountState converts a live claim into the document format, the
+// inverse of loadAccountState - together they let `--export-state` and
+// `--from-file` round-trip an account.
+func exportAccountState(ac *jwt.AccountClaims) *AccountState {
+	s := &AccountState{
+		Name:           ac.Name,
+		Tags:           []string(ac.Tags),
+		Info:           ac.Info,
+		Description:    ac.Description,
+		SigningKeys:    []string(ac.SigningKeys),
+		DisallowBearer: ac.DisallowBearer,
+		PubAllow:       []string(ac.DefaultPermissions.Pub.Allow),
+		PubDeny:        []string(ac.DefaultPermissions.Pub.Deny),
+		SubAllow:       []string(ac.DefaultPermissions.Sub.Allow),
+		SubDeny:        []string(ac.DefaultPermissions.Sub.Deny),
+		Limits:         nonTieredLimits(ac.Limits),
+		Tiers:          ac.Limits.JetStreamTieredLimits,
+	}
+	if ac.NotBefore != 0 {
+		s.NotBefore = fmt.Sprintf("%d", ac.NotBefore)
+	}
+	if ac.Expires != 0 {
+		s.Expires = fmt.Sprintf("%d", ac.Expires)
+	}
+	if ac.DefaultPermissions.Resp != nil {
+		s.MaxResponses = ac.DefaultPermissions.Resp.MaxMsgs
+		s.ResponseTTL = ac.DefaultPermissions.Resp.Expires.String()
+	}
+	return s
+}
+
+// stateDiff is a human-readable record of the fields that differ between
+// an account's current claim and a desired AccountState, produced by
+// diffAccountState and consumed by --dry-run and apply.
+type stateDiff struct {
+	lines []string
+}
+
+func (d *stateDiff) add(field string, from, to interface{}) {
+	d.lines = append(d.lines, fmt.Sprintf("%s: %v -> %v", field, from, to))
+}
+
+func (d *stateDiff) IsEmpty() bool {
+	return len(d.lines) == 0
+}
+
+func (d *stateDiff) String() string {
+	if d.IsEmpty() {
+		return "no changes"
+	}
+	sort.Strings(d.lines)
+	return strings.Join(d.lines, "\n")
+}
+
+// diffAccountState compares the current claim against the desired state.
+// When prune is true, fields present on the claim but absent from the
+// file are reported (and later cleared by apply); when false they are
+// left untouched and never appear in the diff.
+func diffAccountState(ac *jwt.AccountClaims, desired *AccountState, prune bool) *stateDiff {
+	d := &stateDiff{}
+
+	if desired.Info != ac.Info && (desired.Info != "" || prune) {
+		d.add("info_url", ac.Info, desired.Info)
+	}
+	if desired.Description != ac.Description && (desired.Description != "" || prune) {
+		d.add("description", ac.Description, desired.Description)
+	}
+	if (len(desired.Tags) > 0 || prune) && !reflect.DeepEqual(sortedCopy(desired.Tags), sortedCopy([]string(ac.Tags))) {
+		d.add("tags", ac.Tags, desired.Tags)
+	}
+	if (len(desired.SigningKeys) > 0 || prune) && !reflect.DeepEqual(sortedCopy(desired.SigningKeys), sortedCopy([]string(ac.SigningKeys))) {
+		d.add("signing_keys", ac.SigningKeys, desired.SigningKeys)
+	}
+	if desired.DisallowBearer != ac.DisallowBearer && (desired.DisallowBearer || prune) {
+		d.add("disallow_bearer", ac.DisallowBearer, desired.DisallowBearer)
+	}
+	if (len(desired.PubAllow) > 0 || prune) && !reflect.DeepEqual(sortedCopy(desired.PubAllow), sortedCopy([]string(ac.DefaultPermissions.Pub.Allow))) {
+		d.add("pub_allow", ac.DefaultPermissions.Pub.Allow, desired.PubAllow)
+	}
+	if (len(desired.PubDeny) > 0 || prune) && !reflect.DeepEqual(sortedCopy(desired.PubDeny), sortedCopy([]string(ac.DefaultPermissions.Pub.Deny))) {
+		d.add("pub_deny", ac.DefaultPermissions.Pub.Deny, desired.PubDeny)
+	}
+	if (len(desired.SubAllow) > 0 || prune) && !reflect.DeepEqual(sortedCopy(desired.SubAllow), sortedCopy([]string(ac.DefaultPermissions.Sub.Allow))) {
+		d.add("sub_allow", ac.DefaultPermissions.Sub.Allow, desired.SubAllow)
+	}
+	if (len(desired.SubDeny) > 0 || prune) && !reflect.DeepEqual(sortedCopy(desired.SubDeny), sortedCopy([]string(ac.DefaultPermissions.Sub.Deny))) {
+		d.add("sub_deny", ac.DefaultPermissions.Sub.Deny, desired.SubDeny)
+	}
+	if desired.MaxResponses != 0 || desired.ResponseTTL != "" || prune {
+		var want *jwt.ResponsePermission
+		if desired.MaxResponses != 0 || desired.ResponseTTL != "" {
+			want = &jwt.ResponsePermission{MaxMsgs: desired.MaxResponses}
+			if ttl, err := ParseDuration(desired.ResponseTTL); err == nil {
+				want.Expires = ttl
+			}
+		}
+		if !reflect.DeepEqual(want, ac.DefaultPermissions.Resp) {
+			d.add("response_permissions", ac.DefaultPermissions.Resp, want)
+		}
+	}
+	if (!isZeroLimits(desired.Limits) || prune) && !reflect.DeepEqual(desired.Limits, nonTieredLimits(ac.Limits)) {
+		d.add("limits", nonTieredLimits(ac.Limits), desired.Limits)
+	}
+	if (len(desired.Tiers) > 0 || prune) && !reflect.DeepEqual(desired.Tiers, ac.Limits.JetStreamTieredLimits) {
+		d.add("tiers", ac.Limits.JetStreamTieredLimits, desired.Tiers)
+	}
+	return d
+}
+
+// apply writes desired onto ac. Because the YAML->struct model can't tell
+// "field omitted from the file" from "field present but empty", every
+// field here is only overwritten when the file actually sets it (a
+// non-zero value) or when prune is set - matching the gating
+// diffAccountState already reports, so a dry-run diff never lies about
+// what apply is about to do.
+func (d *stateDiff) apply(ac *jwt.AccountClaims, desired *AccountState, prune bool) {
+	if desired.Info != "" || prune {
+		ac.Info = desired.Info
+	}
+	if desired.Description != "" || prune {
+		ac.Description = desired.Description
+	}
+	if len(desired.Tags) > 0 || prune {
+		ac.Tags = jwt.TagList(desired.Tags)
+	}
+	if len(desired.SigningKeys) > 0 || prune {
+		ac.SigningKeys = jwt.StringList(desired.SigningKeys)
+	}
+	if desired.DisallowBearer || prune {
+		ac.DisallowBearer = desired.DisallowBearer
+	}
+	if len(desired.PubAllow) > 0 || prune {
+		ac.DefaultPermissions.Pub.Allow = jwt.StringList(desired.PubAllow)
+	}
+	if len(desired.PubDeny) > 0 || prune {
+		ac.DefaultPermissions.Pub.Deny = jwt.StringList(desired.PubDeny)
+	}
+	if len(desired.SubAllow) > 0 || prune {
+		ac.DefaultPermissions.Sub.Allow = jwt.StringList(desired.SubAllow)
+	}
+	if len(desired.SubDeny) > 0 || prune {
+		ac.DefaultPermissions.Sub.Deny = jwt.StringList(desired.SubDeny)
+	}
+	if desired.MaxResponses != 0 || desired.ResponseTTL != "" {
+		ac.DefaultPermissions.Resp = &jwt.ResponsePermission{MaxMsgs: desired.MaxResponses}
+		if d, err := ParseDuration(desired.ResponseTTL); err == nil {
+			ac.DefaultPermissions.Resp.Expires = d
+		}
+	} else if prune {
+		ac.DefaultPermissions.Resp = nil
+	}
+	if !isZeroLimits(desired.Limits) || prune {
+		tiers := ac.Limits.JetStreamTieredLimits
+		ac.Limits = desired.Limits
+		ac.Limits.JetStreamTieredLimits = tiers
+	}
+	if len(desired.Tiers) > 0 || prune {
+		ac.Limits.JetStreamTieredLimits = desired.Tiers
+	}
+}
+
+// nonTieredLimits returns a copy of l with the tiered map cleared, so
+// comparisons and the exported document treat global and tiered limits
+// as the two independent fields `nsc edit account` already does.
+func nonTieredLimits(l jwt.OperatorLimits) jwt.OperatorLimits {
+	l.JetStreamTieredLimits = nil
+	return l
+}
+
+// isZeroLimits reports whether l is the Go zero value, i.e. the document
+// never set any limit field - the same "was this actually in the file"
+// test the rest of AccountState's fields get via len()/"" checks.
+func isZeroLimits(l jwt.OperatorLimits) bool {
+	return reflect.DeepEqual(l, jwt.OperatorLimits{})
+}
+
+func sortedCopy(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	c := append([]string(nil), s...)
+	sort.Strings(c)
+	return c
+}
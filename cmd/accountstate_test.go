@@ -0,0 +1,178 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EditAccountFromFile(t *testing.T) {
+	ts := NewTestStore(t, "edit account")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+
+	file := filepath.Join(ts.Dir, "a.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+name: A
+description: managed by gitops
+tags:
+  - team:infra
+pub_allow:
+  - foo.>
+`), 0600))
+
+	_, _, err := ExecuteCmd(createEditAccount(), "--from-file", file)
+	require.NoError(t, err)
+
+	ac, err := ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.Equal(t, "managed by gitops", ac.Description)
+	require.ElementsMatch(t, ac.Tags, []string{"team:infra"})
+	require.ElementsMatch(t, ac.DefaultPermissions.Pub.Allow, []string{"foo.>"})
+}
+
+func Test_EditAccountFromFileDryRun(t *testing.T) {
+	ts := NewTestStore(t, "edit account")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+
+	file := filepath.Join(ts.Dir, "a.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+name: A
+description: would change
+`), 0600))
+
+	_, _, err := ExecuteCmd(createEditAccount(), "--from-file", file, "--dry-run")
+	require.NoError(t, err)
+
+	ac, err := ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.Empty(t, ac.Description)
+}
+
+func Test_EditAccountFromFileWithoutPruneKeepsOmittedFields(t *testing.T) {
+	ts := NewTestStore(t, "edit account")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, _, err := ExecuteCmd(createEditAccount(), "--tag", "keep-me", "--sk", "generate")
+	require.NoError(t, err)
+
+	ac, err := ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.ElementsMatch(t, ac.Tags, []string{"keep-me"})
+	require.Len(t, ac.SigningKeys, 1)
+
+	file := filepath.Join(ts.Dir, "a.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+name: A
+description: managed by gitops
+`), 0600))
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--from-file", file)
+	require.NoError(t, err)
+
+	ac, err = ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.Equal(t, "managed by gitops", ac.Description)
+	require.ElementsMatch(t, ac.Tags, []string{"keep-me"})
+	require.Len(t, ac.SigningKeys, 1)
+}
+
+func Test_EditAccountFromFileWithoutPruneKeepsOmittedLimits(t *testing.T) {
+	ts := NewTestStore(t, "edit account")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, _, err := ExecuteCmd(createEditAccount(), "--conns", "10", "--exports", "5")
+	require.NoError(t, err)
+
+	file := filepath.Join(ts.Dir, "a.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+name: A
+description: managed by gitops
+`), 0600))
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--from-file", file)
+	require.NoError(t, err)
+
+	ac, err := ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.Equal(t, "managed by gitops", ac.Description)
+	require.Equal(t, int64(10), ac.Limits.Conn)
+	require.Equal(t, int64(5), ac.Limits.Exports)
+}
+
+func Test_EditAccountFromFilePruneClearsLimits(t *testing.T) {
+	ts := NewTestStore(t, "edit account")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, _, err := ExecuteCmd(createEditAccount(), "--conns", "10")
+	require.NoError(t, err)
+
+	file := filepath.Join(ts.Dir, "a.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+name: A
+`), 0600))
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--from-file", file, "--prune")
+	require.NoError(t, err)
+
+	ac, err := ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), ac.Limits.Conn)
+}
+
+func Test_EditAccountFromFilePrune(t *testing.T) {
+	ts := NewTestStore(t, "edit account")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, _, err := ExecuteCmd(createEditAccount(), "--tag", "keep-me")
+	require.NoError(t, err)
+
+	file := filepath.Join(ts.Dir, "a.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+name: A
+`), 0600))
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--from-file", file, "--prune")
+	require.NoError(t, err)
+
+	ac, err := ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.Empty(t, ac.Tags)
+}
+
+func Test_DescribeAccountExportState(t *testing.T) {
+	ts := NewTestStore(t, "edit account")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, _, err := ExecuteCmd(createEditAccount(), "--description", "exported")
+	require.NoError(t, err)
+
+	stdout, _, err := ExecuteCmd(createDescribeAccountCmd(), "--name", "A", "--export-state")
+	require.NoError(t, err)
+	require.Contains(t, stdout, "exported")
+}
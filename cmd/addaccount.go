@@ -0,0 +1,108 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"github.com/nats-io/nsc/cmd/store"
+	"github.com/spf13/cobra"
+)
+
+func createAddAccountCmd() *cobra.Command {
+	var params AddAccountParams
+	cmd := &cobra.Command{
+		Use:          "account",
+		Short:        "Add an account",
+		Args:         MaxArgs(1),
+		Example:      `nsc add account --name A --apply-plan pro`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunAction(cmd, args, &params)
+		},
+	}
+	cmd.Flags().StringVarP(&params.name, "name", "n", "", "account name")
+	cmd.Flags().StringVarP(&params.applyPlan, "apply-plan", "", "", "start the account on a plan from the operator's plan catalog")
+	params.SignerParams.BindFlags(cmd)
+	return cmd
+}
+
+func init() {
+	addCmd.AddCommand(createAddAccountCmd())
+}
+
+// AddAccountParams holds the flags understood by `nsc add account`.
+type AddAccountParams struct {
+	SignerParams
+	name      string
+	applyPlan string
+}
+
+func (p *AddAccountParams) SetDefaults(ctx ActionCtx) error {
+	return nil
+}
+
+func (p *AddAccountParams) PreInteractive(ctx ActionCtx) error {
+	return nil
+}
+
+func (p *AddAccountParams) Load(ctx ActionCtx) error {
+	return nil
+}
+
+func (p *AddAccountParams) PostInteractive(ctx ActionCtx) error {
+	return nil
+}
+
+func (p *AddAccountParams) Validate(ctx ActionCtx) error {
+	if p.name == "" {
+		return errAccountNameRequired
+	}
+	if p.applyPlan != "" {
+		if _, err := loadPlan(ctx, p.applyPlan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *AddAccountParams) Run(ctx ActionCtx) (store.Status, error) {
+	r := store.NewDetailedReport(true)
+	ac, err := NewAccountClaim(p.name, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.applyPlan != "" {
+		plan, err := loadPlan(ctx, p.applyPlan)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyPlanLimits(ac, plan); err != nil {
+			return nil, err
+		}
+		setPlanTag(ac, plan.Name)
+		r.AddOK("started account %q on plan %q", p.name, plan.Name)
+	}
+
+	token, err := ac.Encode(p.signerKP)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.StoreCtx().Store.StoreClaim([]byte(token)); err != nil {
+		return nil, err
+	}
+	r.AddOK("added account %q", p.name)
+	return r, nil
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nsc/cmd/store"
+	"github.com/spf13/cobra"
+)
+
+// CreateAddUserCmd is exported so other commands (e.g. bulk import) can
+// drive user creation programmatically, matching the existing nsc
+// convention of exporting the "add" half of a resource's CRUD commands.
+func CreateAddUserCmd() *cobra.Command {
+	var params AddUserParams
+	cmd := &cobra.Command{
+		Use:          "user",
+		Short:        "Add a user to an account",
+		Args:         MaxArgs(1),
+		Example:      `nsc add user --name U --account A`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunAction(cmd, args, &params)
+		},
+	}
+	cmd.Flags().StringVarP(&params.name, "name", "n", "", "user name")
+	cmd.Flags().BoolVarP(&params.bearer, "bearer", "", false, "no connect challenge required for user")
+	cmd.Flags().StringSliceVarP(&params.allowPub, "allow-pub", "", nil, "publish permissions - comma separated list or option can be specified multiple times")
+	cmd.Flags().StringSliceVarP(&params.allowSub, "allow-sub", "", nil, "subscribe permissions - comma separated list or option can be specified multiple times")
+	cmd.Flags().StringSliceVarP(&params.allowPubSub, "allow-pubsub", "", nil, "publish and subscribe permissions - comma separated list or option can be specified multiple times")
+	params.AccountContextParams.BindFlags(cmd)
+	return cmd
+}
+
+func init() {
+	addCmd.AddCommand(CreateAddUserCmd())
+}
+
+// AddUserParams holds the flags understood by `nsc add user`.
+type AddUserParams struct {
+	AccountContextParams
+	SignerParams
+	name        string
+	bearer      bool
+	allowPub    []string
+	allowSub    []string
+	allowPubSub []string
+}
+
+func (p *AddUserParams) SetDefaults(ctx ActionCtx) error {
+	p.AccountContextParams.SetDefaults(ctx)
+	return nil
+}
+
+func (p *AddUserParams) PreInteractive(ctx ActionCtx) error {
+	return p.AccountContextParams.Edit(ctx)
+}
+
+func (p *AddUserParams) Load(ctx ActionCtx) error {
+	return p.AccountContextParams.Validate(ctx)
+}
+
+func (p *AddUserParams) PostInteractive(ctx ActionCtx) error {
+	return nil
+}
+
+func (p *AddUserParams) permissions() jwt.Permissions {
+	var perm jwt.Permissions
+	for _, s := range p.allowPub {
+		perm.Pub.Allow.Add(s)
+	}
+	for _, s := range p.allowSub {
+		perm.Sub.Allow.Add(s)
+	}
+	for _, s := range p.allowPubSub {
+		perm.Pub.Allow.Add(s)
+		perm.Sub.Allow.Add(s)
+	}
+	return perm
+}
+
+func (p *AddUserParams) Validate(ctx ActionCtx) error {
+	if p.name == "" {
+		return fmt.Errorf("user name is required")
+	}
+	ac, err := ctx.StoreCtx().Store.ReadAccountClaim(p.AccountContextParams.Name)
+	if err != nil {
+		return err
+	}
+	if p.bearer && ac.DisallowBearer {
+		return fmt.Errorf("account %q forbids the use of bearer token", ac.Name)
+	}
+	if err := requireExplicitAllowRules(ctx, ac.Subject, p.permissions()); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *AddUserParams) Run(ctx ActionCtx) (store.Status, error) {
+	r := store.NewDetailedReport(true)
+	uc := jwt.NewUserClaims(ctx.StoreCtx().Store.NextNKey())
+	uc.Name = p.name
+	uc.BearerToken = p.bearer
+	uc.Permissions = p.permissions()
+
+	ac, err := ctx.StoreCtx().Store.ReadAccountClaim(p.AccountContextParams.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := uc.Encode(p.signerKP)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.StoreCtx().Store.StoreClaim([]byte(token)); err != nil {
+		return nil, err
+	}
+	r.AddOK("added user %q to account %q", p.name, ac.Name)
+	return r, nil
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nsc/cmd/store"
+)
+
+// defaultDenyTag marks an account that was put into --default-deny mode,
+// so later edits and user creation can detect the mode without having to
+// re-derive it from the deny lists alone.
+const defaultDenyTag = "default-deny"
+
+func setDefaultDenyTag(ac *jwt.AccountClaims) {
+	if !ac.Tags.Contains(defaultDenyTag) {
+		ac.Tags.Add(defaultDenyTag)
+	}
+}
+
+func isDefaultDenyAccount(ac *jwt.AccountClaims) bool {
+	return ac.Tags.Contains(defaultDenyTag)
+}
+
+// requireNoWideOpenUsers refuses to enable --default-deny while any
+// existing user in the account has no allow rules of its own - such a
+// user would otherwise be silently cut off from everything once the
+// account-level deny-all baseline takes effect.
+func requireNoWideOpenUsers(ctx ActionCtx, accountSubject string) error {
+	accountName := ctx.StoreCtx().Store.GetAccountName(accountSubject)
+	users, err := ctx.StoreCtx().Store.ListEntries(store.Users, accountSubject)
+	if err != nil {
+		return err
+	}
+	var offenders []string
+	for _, u := range users {
+		uc, err := ctx.StoreCtx().Store.ReadUserClaim(accountSubject, u)
+		if err != nil {
+			return err
+		}
+		if isWideOpen(uc.Permissions) {
+			offenders = append(offenders, u)
+		}
+	}
+	if len(offenders) > 0 {
+		return fmt.Errorf("account %q has users with no allow rules, enable --default-deny after narrowing them: %v", accountName, offenders)
+	}
+	return nil
+}
+
+func isWideOpen(p jwt.Permissions) bool {
+	return len(p.Pub.Allow) == 0 && len(p.Sub.Allow) == 0
+}
+
+// requireExplicitAllowRules is the check CreateAddUserCmd and the user
+// edit command run before saving a user in a --default-deny account - it
+// is the user-creation-time mirror of requireNoWideOpenUsers.
+func requireExplicitAllowRules(ctx ActionCtx, accountSubject string, p jwt.Permissions) error {
+	ac, err := ctx.StoreCtx().Store.ReadAccountClaim(ctx.StoreCtx().Store.GetAccountName(accountSubject))
+	if err != nil {
+		return err
+	}
+	if !isDefaultDenyAccount(ac) {
+		return nil
+	}
+	if isWideOpen(p) {
+		return fmt.Errorf("account %q is in default-deny mode, specify --allow-pub/--allow-sub or --allow-pubsub", ac.Name)
+	}
+	return nil
+}
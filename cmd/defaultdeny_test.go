@@ -0,0 +1,75 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EditAccountDefaultDenyRefusesWideOpenUsers(t *testing.T) {
+	ts := NewTestStore(t, "default deny")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	ts.AddUser(t, "A", "U")
+
+	_, _, err := ExecuteCmd(createEditAccount(), "--name", "A", "--default-deny")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "U")
+}
+
+func Test_EditAccountDefaultDenyBlocksWideOpenUserCreation(t *testing.T) {
+	ts := NewTestStore(t, "default deny")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, _, err := ExecuteCmd(createEditAccount(), "--name", "A", "--default-deny")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(CreateAddUserCmd(), "--account", "A", "--name", "U")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "default-deny mode")
+
+	_, _, err = ExecuteCmd(CreateAddUserCmd(), "--account", "A", "--name", "U", "--allow-pub", "foo.>")
+	require.NoError(t, err)
+}
+
+func Test_EditAccountDefaultDenyRoundTripsThroughDescribe(t *testing.T) {
+	ts := NewTestStore(t, "default deny")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, _, err := ExecuteCmd(createEditAccount(), "--name", "A", "--default-deny")
+	require.NoError(t, err)
+
+	ac, err := ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.ElementsMatch(t, ac.DefaultPermissions.Pub.Deny, []string{">"})
+	require.ElementsMatch(t, ac.DefaultPermissions.Sub.Deny, []string{">"})
+	require.True(t, isDefaultDenyAccount(ac))
+
+	stdout, _, err := ExecuteCmd(createDescribeAccountCmd(), "--name", "A", "--export-state")
+	require.NoError(t, err)
+	require.Contains(t, stdout, defaultDenyTag)
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--name", "A", "--rm-default-deny")
+	require.NoError(t, err)
+	ac, err = ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.False(t, isDefaultDenyAccount(ac))
+}
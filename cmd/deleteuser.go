@@ -0,0 +1,53 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"github.com/nats-io/nsc/cmd/store"
+	"github.com/spf13/cobra"
+)
+
+func createDeleteUserCmd() *cobra.Command {
+	var accountName string
+	var name string
+	cmd := &cobra.Command{
+		Use:          "user",
+		Short:        "Delete a user",
+		Args:         MaxArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := NewActionCtx(cmd, args)
+			if err != nil {
+				return err
+			}
+			if accountName == "" {
+				accountName = ctx.StoreCtx().Account.Name
+			}
+			if err := ctx.StoreCtx().Store.Delete(store.Users, accountName, name); err != nil {
+				return err
+			}
+			cmd.Printf("deleted user %q\n", name)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&accountName, "account", "", "", "account name")
+	cmd.Flags().StringVarP(&name, "name", "n", "", "user name")
+	return cmd
+}
+
+func init() {
+	deleteCmd.AddCommand(createDeleteUserCmd())
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func createDescribeAccountCmd() *cobra.Command {
+	var name string
+	var exportState bool
+	cmd := &cobra.Command{
+		Use:          "account",
+		Short:        "Describe an account",
+		Args:         MaxArgs(1),
+		Example:      `nsc describe account --name A --export-state > a.yaml`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := NewActionCtx(cmd, args)
+			if err != nil {
+				return err
+			}
+			if name == "" && len(args) > 0 {
+				name = args[0]
+			}
+			if name == "" {
+				name = ctx.StoreCtx().Account.Name
+			}
+			ac, err := ctx.StoreCtx().Store.ReadAccountClaim(name)
+			if err != nil {
+				return err
+			}
+			if !exportState {
+				return describeClaim(cmd, ac)
+			}
+			data, err := yaml.Marshal(exportAccountState(ac))
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(data))
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&name, "name", "n", "", "account name")
+	cmd.Flags().BoolVarP(&exportState, "export-state", "", false, "print the account's desired-state document, the same format read by 'nsc edit account --from-file'")
+	return cmd
+}
+
+func init() {
+	describeCmd.AddCommand(createDescribeAccountCmd())
+}
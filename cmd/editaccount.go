@@ -0,0 +1,667 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nsc/cmd/store"
+	"github.com/spf13/cobra"
+)
+
+func createEditAccount() *cobra.Command {
+	var params EditAccountParams
+	cmd := &cobra.Command{
+		Use:          "account",
+		Short:        "Edit an account",
+		Args:         MaxArgs(1),
+		Example:      `nsc edit account --name A --tag test`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunAction(cmd, args, &params)
+		},
+	}
+	cmd.Flags().StringVarP(&params.infoUrl, "info-url", "", "", "link for more info on the account")
+	cmd.Flags().StringVarP(&params.description, "description", "", "", "description for this account")
+	cmd.Flags().StringSliceVarP(&params.tags, "tag", "", nil, "add tags for account - comma separated list or option can be specified multiple times")
+	cmd.Flags().StringSliceVarP(&params.rmTags, "rm-tag", "", nil, "remove tag - comma separated list or option can be specified multiple times")
+	cmd.Flags().StringVarP(&params.start, "start", "", "", "valid from ('0' is always, '3d' is three days, YYYY-MM-DD, or Unix timestamp)")
+	cmd.Flags().StringVarP(&params.expiry, "expiry", "", "", "valid until ('0' is always, '2M' is two months, YYYY-MM-DD, or Unix timestamp)")
+
+	cmd.Flags().Int64VarP(&params.conns, "conns", "", 0, "set maximum active connections for the account (-1 is unlimited)")
+	cmd.Flags().StringVarP(&params.data, "data", "", "", "set maximum data in bytes for the account (-1 is unlimited)")
+	cmd.Flags().Int64VarP(&params.exports, "exports", "", 0, "set maximum number of exports for the account (-1 is unlimited)")
+	cmd.Flags().Int64VarP(&params.imports, "imports", "", 0, "set maximum number of imports for the account (-1 is unlimited)")
+	cmd.Flags().StringVarP(&params.payload, "payload", "", "", "set maximum message payload in bytes for the account (-1 is unlimited)")
+	cmd.Flags().Int64VarP(&params.subscriptions, "subscriptions", "", 0, "set maximum number of subscriptions for the account (-1 is unlimited)")
+	cmd.Flags().Int64VarP(&params.leafNodeConns, "leaf-conns", "", 0, "set maximum number of leafnode connections for the account (-1 is unlimited)")
+
+	cmd.Flags().Int64VarP(&params.jsTier, "js-tier", "", 0, "enable jetstream on a tiered limit and set it to this tier")
+	cmd.Flags().StringVarP(&params.rmJsTier, "rm-js-tier", "", "", "remove jetstream limits for a tier")
+	cmd.Flags().BoolVarP(&params.jsDisable, "js-disable", "", false, "disable jetstream")
+	cmd.Flags().Int64VarP(&params.jsStreams, "js-streams", "", 0, "set maximum number of streams for the account (-1 is unlimited)")
+	cmd.Flags().Int64VarP(&params.jsConsumer, "js-consumer", "", 0, "set maximum number of consumer for the account (-1 is unlimited)")
+	cmd.Flags().StringVarP(&params.jsDiskStorage, "js-disk-storage", "", "", "set maximum disk storage in bytes for the account (-1 is unlimited)")
+	cmd.Flags().StringVarP(&params.jsMemStorage, "js-mem-storage", "", "", "set maximum memory storage in bytes for the account (-1 is unlimited)")
+	cmd.Flags().StringVarP(&params.jsMaxDiskStream, "js-max-disk-stream", "", "", "set maximum disk a stream can have in the account (-1 is unlimited)")
+	cmd.Flags().StringVarP(&params.jsMaxMemStream, "js-max-mem-stream", "", "", "set maximum memory a stream can have in the account (-1 is unlimited)")
+	cmd.Flags().Int64VarP(&params.jsMaxAckPending, "js-max-ack-pending", "", 0, "set maximum ack pending for a consumer in the account (-1 is unlimited)")
+	cmd.Flags().BoolVarP(&params.jsMaxBytesRequired, "js-max-bytes-required", "", false, "set if streams in the account require a max bytes setting")
+
+	cmd.Flags().StringSliceVarP(&params.sk, "sk", "", nil, "add signing key, or 'generate' to generate a key pair")
+	cmd.Flags().StringSliceVarP(&params.rmSk, "rm-sk", "", nil, "remove signing key")
+	cmd.Flags().BoolVarP(&params.rotateSk, "rotate-sk", "", false, "generate a new signing key and schedule the account's active signing key for retirement")
+	cmd.Flags().DurationVarP(&params.overlap, "overlap", "", 0, "with --rotate-sk, how long the retiring key stays valid alongside the new one")
+	cmd.Flags().StringVarP(&params.rotateFrom, "rotate-from", "", "", "with --rotate-sk, the signing key to retire, when the account has more than one")
+	cmd.Flags().StringVarP(&params.expireSk, "expire-sk", "", "", "schedule retirement for this signing key")
+	cmd.Flags().StringVarP(&params.expireAt, "at", "", "", "with --expire-sk, the time the key should retire")
+
+	cmd.Flags().StringSliceVarP(&params.allowPub, "allow-pub", "", nil, "add publish permissions - comma separated list or option can be specified multiple times")
+	cmd.Flags().StringSliceVarP(&params.allowPubSub, "allow-pubsub", "", nil, "add publish and subscribe permissions - comma separated list or option can be specified multiple times")
+	cmd.Flags().StringSliceVarP(&params.denyPub, "deny-pub", "", nil, "add deny publish permissions - comma separated list or option can be specified multiple times")
+	cmd.Flags().StringSliceVarP(&params.denyPubSub, "deny-pubsub", "", nil, "add deny publish and subscribe permissions - comma separated list or option can be specified multiple times")
+	cmd.Flags().StringSliceVarP(&params.rm, "rm", "", nil, "remove publish/subscribe permissions - comma separated list or option can be specified multiple times")
+
+	cmd.Flags().IntVarP(&params.maxResponses, "max-responses", "", 0, "set max responses for the default permissions response permissions")
+	cmd.Flags().StringVarP(&params.responseTTL, "response-ttl", "", "", "set response ttl for the default permissions response permissions")
+	cmd.Flags().BoolVarP(&params.rmResponsePerms, "rm-response-perms", "", false, "remove response permissions")
+
+	cmd.Flags().BoolVarP(&params.disallowBearer, "disallow-bearer", "", false, "require user jwt to not be bearer token")
+
+	cmd.Flags().BoolVarP(&params.defaultDeny, "default-deny", "", false, "seed the account's default permissions with a deny-all baseline and require every user to carve out explicit allow rules")
+	cmd.Flags().BoolVarP(&params.rmDefaultDeny, "rm-default-deny", "", false, "remove the deny-all baseline set by --default-deny")
+
+	cmd.Flags().StringVarP(&params.applyPlan, "apply-plan", "", "", "apply a plan from the operator's plan catalog, setting all limits the plan defines")
+
+	cmd.Flags().StringVarP(&params.fromFile, "from-file", "", "", "converge the account to the desired state described in this YAML/JSON file")
+	cmd.Flags().BoolVarP(&params.dryRun, "dry-run", "", false, "with --from-file, print the diff against the current account without writing it")
+	cmd.Flags().BoolVarP(&params.prune, "prune", "", false, "with --from-file, clear fields that are absent from the file instead of leaving them alone")
+
+	params.AccountContextParams.BindFlags(cmd)
+	params.SignerParams.BindFlags(cmd)
+
+	return cmd
+}
+
+func init() {
+	editCmd.AddCommand(createEditAccount())
+}
+
+// EditAccountParams holds all the flags understood by `nsc edit account`.
+type EditAccountParams struct {
+	AccountContextParams
+	SignerParams
+	GenericClaimsParams
+
+	claim *jwt.AccountClaims
+
+	infoUrl     string
+	description string
+	tags        []string
+	rmTags      []string
+	start       string
+	expiry      string
+
+	conns         int64
+	data          string
+	exports       int64
+	imports       int64
+	payload       string
+	subscriptions int64
+	leafNodeConns int64
+
+	jsTier             int64
+	rmJsTier           string
+	jsDisable          bool
+	jsStreams          int64
+	jsConsumer         int64
+	jsDiskStorage      string
+	jsMemStorage       string
+	jsMaxDiskStream    string
+	jsMaxMemStream     string
+	jsMaxAckPending    int64
+	jsMaxBytesRequired bool
+
+	sk         []string
+	rmSk       []string
+	rotateSk   bool
+	overlap    time.Duration
+	rotateFrom string
+	expireSk   string
+	expireAt   string
+
+	allowPub    []string
+	allowPubSub []string
+	denyPub     []string
+	denyPubSub  []string
+	rm          []string
+
+	maxResponses    int
+	responseTTL     string
+	rmResponsePerms bool
+
+	disallowBearer bool
+
+	defaultDeny   bool
+	rmDefaultDeny bool
+
+	applyPlan string
+
+	fromFile string
+	dryRun   bool
+	prune    bool
+
+	raw []string
+}
+
+func (p *EditAccountParams) SetDefaults(ctx ActionCtx) error {
+	p.AccountContextParams.SetDefaults(ctx)
+	p.raw = rawFlagSet(ctx.Cmd())
+	return nil
+}
+
+func (p *EditAccountParams) PreInteractive(ctx ActionCtx) error {
+	return p.AccountContextParams.Edit(ctx)
+}
+
+func (p *EditAccountParams) Load(ctx ActionCtx) error {
+	if err := p.AccountContextParams.Validate(ctx); err != nil {
+		return err
+	}
+	claim, err := ctx.StoreCtx().Store.ReadAccountClaim(p.AccountContextParams.Name)
+	if err != nil {
+		return err
+	}
+	p.claim = claim
+	return nil
+}
+
+func (p *EditAccountParams) PostInteractive(_ ActionCtx) error {
+	return nil
+}
+
+func (p *EditAccountParams) Validate(ctx ActionCtx) error {
+	if len(p.raw) == 0 {
+		return fmt.Errorf("specify an edit option")
+	}
+
+	if ctx.StoreCtx().Store.IsSystemAccount(p.claim.Name) {
+		for _, f := range p.raw {
+			if strings.HasPrefix(f, "js-") {
+				return fmt.Errorf("%s is not supported on the system account", f)
+			}
+		}
+	}
+
+	if p.applyPlan != "" {
+		plan, err := loadPlan(ctx, p.applyPlan)
+		if err != nil {
+			return err
+		}
+		if err := plan.Limits.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if hasFlag(p.raw, "js-disable") && hasAnyOtherJsFlag(p.raw, "js-disable") {
+		return fmt.Errorf("js-disable is exclusive of all other js options")
+	}
+	if hasFlag(p.raw, "rm-js-tier") && hasAnyOtherJsFlag(p.raw, "rm-js-tier") {
+		return fmt.Errorf("rm-js-tier is exclusive of all other js options")
+	}
+
+	if hasFlag(p.raw, "disallow-bearer") {
+		if err := requireNoBearerUsers(ctx, p.claim.Subject); err != nil {
+			return err
+		}
+	}
+
+	if p.defaultDeny {
+		if err := requireNoWideOpenUsers(ctx, p.claim.Subject); err != nil {
+			return err
+		}
+	}
+
+	if p.rotateSk && p.overlap <= 0 {
+		return fmt.Errorf("--rotate-sk requires --overlap")
+	}
+	if p.expireSk != "" && p.expireAt == "" {
+		return fmt.Errorf("--expire-sk requires --at")
+	}
+	if hasFlag(p.raw, "sk") || hasFlag(p.raw, "rotate-sk") {
+		if err := requireAnActiveSk(ctx, p.claim.SigningKeys); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *EditAccountParams) Run(ctx ActionCtx) (store.Status, error) {
+	r := store.NewDetailedReport(true)
+	ac := p.claim
+
+	if p.applyPlan != "" {
+		plan, err := loadPlan(ctx, p.applyPlan)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyPlanLimits(ac, plan); err != nil {
+			return nil, err
+		}
+		setPlanTag(ac, plan.Name)
+		r.AddOK("applied plan %q", plan.Name)
+	}
+
+	if hasFlag(p.raw, "info-url") {
+		ac.Info = p.infoUrl
+		r.AddOK("changed info url to %q", p.infoUrl)
+	}
+	if hasFlag(p.raw, "description") {
+		ac.Description = p.description
+		r.AddOK("changed description to %q", p.description)
+	}
+
+	ac.Tags.Add(p.tags...)
+	ac.Tags.Remove(p.rmTags...)
+
+	if p.start != "" {
+		at, err := ParseExpiry(p.start)
+		if err != nil {
+			return nil, err
+		}
+		ac.NotBefore = at
+	}
+	if p.expiry != "" {
+		at, err := ParseExpiry(p.expiry)
+		if err != nil {
+			return nil, err
+		}
+		ac.Expires = at
+	}
+
+	if err := p.applyLimits(ac); err != nil {
+		return nil, err
+	}
+
+	if err := p.applySigningKeys(ac); err != nil {
+		return nil, err
+	}
+
+	if p.rotateSk {
+		newPub, retiring, err := p.rotateSigningKey(ctx, ac)
+		if err != nil {
+			return nil, err
+		}
+		if retiring != "" {
+			r.AddOK("generated signing key %q, retiring %q after %s", newPub, retiring, p.overlap)
+		} else {
+			r.AddOK("generated signing key %q, no prior signing key to retire", newPub)
+		}
+	}
+	if p.expireSk != "" {
+		at, err := ParseExpiry(p.expireAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := scheduleSkExpiry(ctx, p.expireSk, time.Unix(at, 0)); err != nil {
+			return nil, err
+		}
+		r.AddOK("scheduled signing key %q to retire at %s", p.expireSk, p.expireAt)
+	}
+
+	p.applyPermissions(ac)
+
+	if hasFlag(p.raw, "disallow-bearer") {
+		ac.DisallowBearer = true
+		r.AddOK("account disallows bearer tokens")
+	}
+
+	if p.defaultDeny {
+		ac.DefaultPermissions.Pub.Deny.Add(">")
+		ac.DefaultPermissions.Sub.Deny.Add(">")
+		setDefaultDenyTag(ac)
+		r.AddOK("account now denies all pub/sub by default; users must carve out explicit allow rules")
+	}
+	if p.rmDefaultDeny {
+		ac.DefaultPermissions.Pub.Deny.Remove(">")
+		ac.DefaultPermissions.Sub.Deny.Remove(">")
+		ac.Tags.Remove(defaultDenyTag)
+		r.AddOK("account no longer denies all pub/sub by default")
+	}
+
+	if p.fromFile != "" {
+		desired, err := loadAccountState(p.fromFile)
+		if err != nil {
+			return nil, err
+		}
+		diff := diffAccountState(ac, desired, p.prune)
+		if p.dryRun {
+			r.AddOK("%s", diff.String())
+			return r, nil
+		}
+		diff.apply(ac, desired, p.prune)
+		if diff.IsEmpty() {
+			r.AddOK("account %q already matches %q", ac.Name, p.fromFile)
+		} else {
+			r.AddOK("converged account %q to %q:\n%s", ac.Name, p.fromFile, diff.String())
+		}
+	}
+
+	token, err := ac.Encode(p.signerKP)
+	if err != nil {
+		return nil, err
+	}
+	storeName := ctx.StoreCtx().Store.GetName()
+	if err := ctx.StoreCtx().Store.StoreClaim([]byte(token)); err != nil {
+		return nil, err
+	}
+	r.AddOK("edited account %q in %q", ac.Name, storeName)
+	return r, nil
+}
+
+func (p *EditAccountParams) applyLimits(ac *jwt.AccountClaims) error {
+	if hasFlag(p.raw, "conns") {
+		ac.Limits.Conn = p.conns
+	}
+	if hasFlag(p.raw, "data") {
+		v, err := ParseDataSize(p.data)
+		if err != nil {
+			return err
+		}
+		ac.Limits.Data = v
+	}
+	if hasFlag(p.raw, "exports") {
+		ac.Limits.Exports = p.exports
+	}
+	if hasFlag(p.raw, "imports") {
+		ac.Limits.Imports = p.imports
+	}
+	if hasFlag(p.raw, "payload") {
+		v, err := ParseDataSize(p.payload)
+		if err != nil {
+			return err
+		}
+		ac.Limits.Payload = v
+	}
+	if hasFlag(p.raw, "subscriptions") {
+		ac.Limits.Subs = p.subscriptions
+	}
+	if hasFlag(p.raw, "leaf-conns") {
+		ac.Limits.LeafNodeConn = p.leafNodeConns
+	}
+	return p.applyJsLimits(ac)
+}
+
+func (p *EditAccountParams) applyJsLimits(ac *jwt.AccountClaims) error {
+	if hasFlag(p.raw, "rm-js-tier") {
+		tier, err := parseJsTier(p.rmJsTier)
+		if err != nil {
+			return err
+		}
+		if tier == 0 {
+			ac.Limits.JetStreamLimits = jwt.JetStreamLimits{}
+		} else {
+			delete(ac.Limits.JetStreamTieredLimits, fmt.Sprintf("R%d", tier))
+		}
+		return nil
+	}
+	if hasFlag(p.raw, "js-disable") {
+		ac.Limits.JetStreamLimits = jwt.JetStreamLimits{}
+		ac.Limits.JetStreamTieredLimits = nil
+		return nil
+	}
+
+	settingTiered := hasFlag(p.raw, "js-tier")
+	settingJs := hasAnyJsFlag(p.raw)
+
+	if settingTiered {
+		if err := checkJsTierConflict(ac); err != nil {
+			return err
+		}
+		if ac.Limits.JetStreamTieredLimits == nil {
+			ac.Limits.JetStreamTieredLimits = make(map[string]jwt.JetStreamLimits)
+		}
+		tierName := fmt.Sprintf("R%d", p.jsTier)
+		tl := ac.Limits.JetStreamTieredLimits[tierName]
+		if err := p.fillJsLimits(&tl); err != nil {
+			return err
+		}
+		ac.Limits.JetStreamTieredLimits[tierName] = tl
+		return nil
+	}
+
+	if settingJs {
+		if err := checkJsGlobalConflict(ac); err != nil {
+			return err
+		}
+	}
+
+	if settingJs {
+		if err := p.fillJsLimits(&ac.Limits.JetStreamLimits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *EditAccountParams) fillJsLimits(l *jwt.JetStreamLimits) error {
+	if hasFlag(p.raw, "js-streams") {
+		l.Streams = p.jsStreams
+	}
+	if hasFlag(p.raw, "js-consumer") {
+		l.Consumer = p.jsConsumer
+	}
+	if hasFlag(p.raw, "js-disk-storage") {
+		v, err := ParseDataSize(p.jsDiskStorage)
+		if err != nil {
+			return err
+		}
+		l.DiskStorage = v
+	}
+	if hasFlag(p.raw, "js-mem-storage") {
+		v, err := ParseDataSize(p.jsMemStorage)
+		if err != nil {
+			return err
+		}
+		l.MemoryStorage = v
+	}
+	if hasFlag(p.raw, "js-max-disk-stream") {
+		v, err := ParseDataSize(p.jsMaxDiskStream)
+		if err != nil {
+			return err
+		}
+		l.DiskMaxStreamBytes = v
+	}
+	if hasFlag(p.raw, "js-max-mem-stream") {
+		v, err := ParseDataSize(p.jsMaxMemStream)
+		if err != nil {
+			return err
+		}
+		l.MemoryMaxStreamBytes = v
+	}
+	if hasFlag(p.raw, "js-max-ack-pending") {
+		l.MaxAckPending = p.jsMaxAckPending
+	}
+	if hasFlag(p.raw, "js-max-bytes-required") {
+		l.MaxBytesRequired = p.jsMaxBytesRequired
+	}
+	return nil
+}
+
+func (p *EditAccountParams) applySigningKeys(ac *jwt.AccountClaims) error {
+	for _, k := range p.sk {
+		if k == "generate" {
+			kp, err := CreateAccountKey2()
+			if err != nil {
+				return err
+			}
+			pub, err := kp.PublicKey()
+			if err != nil {
+				return err
+			}
+			ac.SigningKeys.Add(pub)
+			continue
+		}
+		ac.SigningKeys.Add(k)
+	}
+	for _, k := range p.rmSk {
+		ac.SigningKeys.Remove(k)
+	}
+	return nil
+}
+
+// rotateSigningKey generates a new signing key, adds it to the account,
+// and schedules the account's existing active signing key for retirement
+// after p.overlap - both keys remain valid signing keys for that window
+// so in-flight user JWTs keep verifying until the reissue sweep catches
+// up. The key being retired is resolved from ac.SigningKeys itself, not
+// from the key used to authorize this edit (p.signerKP): user JWTs are
+// issued by an account signing key, never by the operator or the
+// account's own identity key, so that's the keyspace reissue and
+// `nsc list keys` actually look at.
+func (p *EditAccountParams) rotateSigningKey(ctx ActionCtx, ac *jwt.AccountClaims) (newPub string, retiring string, err error) {
+	retiring, err = resolveSkToRetire(ac.SigningKeys, p.rotateFrom)
+	if err != nil {
+		return "", "", err
+	}
+	kp, err := CreateAccountKey2()
+	if err != nil {
+		return "", "", err
+	}
+	newPub, err = kp.PublicKey()
+	if err != nil {
+		return "", "", err
+	}
+	ac.SigningKeys.Add(newPub)
+	if retiring != "" {
+		if err := scheduleSkExpiry(ctx, retiring, time.Now().Add(p.overlap)); err != nil {
+			return "", "", err
+		}
+	}
+	return newPub, retiring, nil
+}
+
+func (p *EditAccountParams) applyPermissions(ac *jwt.AccountClaims) {
+	for _, s := range p.allowPub {
+		ac.DefaultPermissions.Pub.Allow.Add(s)
+	}
+	for _, s := range p.allowPubSub {
+		ac.DefaultPermissions.Pub.Allow.Add(s)
+		ac.DefaultPermissions.Sub.Allow.Add(s)
+	}
+	for _, s := range p.denyPub {
+		ac.DefaultPermissions.Pub.Deny.Add(s)
+	}
+	for _, s := range p.denyPubSub {
+		ac.DefaultPermissions.Pub.Deny.Add(s)
+		ac.DefaultPermissions.Sub.Deny.Add(s)
+	}
+	for _, s := range p.rm {
+		ac.DefaultPermissions.Pub.Allow.Remove(s)
+		ac.DefaultPermissions.Pub.Deny.Remove(s)
+		ac.DefaultPermissions.Sub.Allow.Remove(s)
+		ac.DefaultPermissions.Sub.Deny.Remove(s)
+	}
+
+	if hasFlag(p.raw, "max-responses") || hasFlag(p.raw, "response-ttl") {
+		if ac.DefaultPermissions.Resp == nil {
+			ac.DefaultPermissions.Resp = &jwt.ResponsePermission{}
+		}
+		if hasFlag(p.raw, "max-responses") {
+			ac.DefaultPermissions.Resp.MaxMsgs = p.maxResponses
+		}
+		if hasFlag(p.raw, "response-ttl") {
+			d, err := ParseDuration(p.responseTTL)
+			if err == nil {
+				ac.DefaultPermissions.Resp.Expires = d
+			}
+		}
+	}
+	if p.rmResponsePerms {
+		ac.DefaultPermissions.Resp = nil
+	}
+}
+
+func hasAnyJsFlag(raw []string) bool {
+	for _, f := range raw {
+		if strings.HasPrefix(f, "js-") && f != "js-tier" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyOtherJsFlag(raw []string, except string) bool {
+	for _, f := range raw {
+		if f == except {
+			continue
+		}
+		if strings.HasPrefix(f, "js-") || f == "rm-js-tier" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkJsTierConflict and checkJsGlobalConflict enforce the rule that an
+// account's JetStream limits are either one flat configuration or a set
+// of per-replica tiers, never both. Both `edit account`'s own js-* flags
+// and plans applying js-* limits go through these so the two paths can't
+// drift apart.
+func checkJsTierConflict(ac *jwt.AccountClaims) error {
+	if !ac.Limits.JetStreamLimits.IsEmpty() {
+		return fmt.Errorf("cannot set a jetstream tier limit when a configuration has a global limit")
+	}
+	return nil
+}
+
+func checkJsGlobalConflict(ac *jwt.AccountClaims) error {
+	if len(ac.Limits.JetStreamTieredLimits) > 0 {
+		return fmt.Errorf("cannot set a jetstream global limit when a configuration has tiered limits %s", quotedTierNames(ac.Limits.JetStreamTieredLimits))
+	}
+	return nil
+}
+
+func quotedTierNames(m map[string]jwt.JetStreamLimits) string {
+	var names []string
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return "'" + strings.Join(names, ",") + "'"
+}
+
+func requireNoBearerUsers(ctx ActionCtx, accountSubject string) error {
+	users, err := ctx.StoreCtx().Store.ListEntries(store.Users, accountSubject)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		uc, err := ctx.StoreCtx().Store.ReadUserClaim(accountSubject, u)
+		if err != nil {
+			return err
+		}
+		if uc.BearerToken {
+			return fmt.Errorf("user %q in account %q uses bearer token (needs to be deleted/changed first)", u, ctx.StoreCtx().Store.GetAccountName(accountSubject))
+		}
+	}
+	return nil
+}
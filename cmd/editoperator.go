@@ -0,0 +1,160 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nsc/cmd/store"
+	"github.com/spf13/cobra"
+)
+
+func createEditOperatorCmd() *cobra.Command {
+	var params EditOperatorParams
+	cmd := &cobra.Command{
+		Use:          "operator",
+		Short:        "Edit the operator",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunAction(cmd, args, &params)
+		},
+	}
+	cmd.Flags().StringVarP(&params.systemAccount, "system-account", "", "", "set the system account")
+	cmd.Flags().StringSliceVarP(&params.sk, "sk", "", nil, "add signing key, or 'generate' to generate a key pair")
+	cmd.Flags().StringSliceVarP(&params.rmSk, "rm-sk", "", nil, "remove signing key")
+	cmd.Flags().BoolVarP(&params.rotateSk, "rotate-sk", "", false, "generate a new operator signing key and schedule the operator's active signing key for retirement")
+	cmd.Flags().DurationVarP(&params.overlap, "overlap", "", 0, "with --rotate-sk, how long the retiring key stays valid alongside the new one")
+	cmd.Flags().StringVarP(&params.rotateFrom, "rotate-from", "", "", "with --rotate-sk, the signing key to retire, when the operator has more than one")
+	return cmd
+}
+
+func init() {
+	editCmd.AddCommand(createEditOperatorCmd())
+}
+
+// EditOperatorParams holds the flags understood by `nsc edit operator`.
+type EditOperatorParams struct {
+	SignerParams
+	systemAccount string
+	sk            []string
+	rmSk          []string
+	rotateSk      bool
+	overlap       time.Duration
+	rotateFrom    string
+	claim         *jwt.OperatorClaims
+}
+
+func (p *EditOperatorParams) SetDefaults(ctx ActionCtx) error {
+	return nil
+}
+
+func (p *EditOperatorParams) PreInteractive(ctx ActionCtx) error {
+	return nil
+}
+
+func (p *EditOperatorParams) Load(ctx ActionCtx) error {
+	claim, err := ctx.StoreCtx().Store.ReadOperatorClaim()
+	if err != nil {
+		return err
+	}
+	p.claim = claim
+	return nil
+}
+
+func (p *EditOperatorParams) PostInteractive(ctx ActionCtx) error {
+	return nil
+}
+
+func (p *EditOperatorParams) Validate(ctx ActionCtx) error {
+	if p.rotateSk && p.overlap <= 0 {
+		return fmt.Errorf("--rotate-sk requires --overlap")
+	}
+	return nil
+}
+
+func (p *EditOperatorParams) Run(ctx ActionCtx) (store.Status, error) {
+	r := store.NewDetailedReport(true)
+	oc := p.claim
+
+	if p.systemAccount != "" {
+		ac, err := ctx.StoreCtx().Store.ReadAccountClaim(p.systemAccount)
+		if err != nil {
+			return nil, err
+		}
+		oc.SystemAccount = ac.Subject
+		r.AddOK("set system account to %q", p.systemAccount)
+	}
+
+	for _, k := range p.sk {
+		if k == "generate" {
+			kp, err := CreateOperatorKey2()
+			if err != nil {
+				return nil, err
+			}
+			pub, err := kp.PublicKey()
+			if err != nil {
+				return nil, err
+			}
+			oc.SigningKeys.Add(pub)
+			continue
+		}
+		oc.SigningKeys.Add(k)
+	}
+	for _, k := range p.rmSk {
+		oc.SigningKeys.Remove(k)
+	}
+
+	if p.rotateSk {
+		// The key being retired is resolved from oc.SigningKeys itself, not
+		// from p.signerKP: accounts are issued by an operator signing key,
+		// never by the operator's own identity key, so that's the keyspace
+		// whose retirement matters.
+		retiring, err := resolveSkToRetire(oc.SigningKeys, p.rotateFrom)
+		if err != nil {
+			return nil, err
+		}
+		newKP, err := CreateOperatorKey2()
+		if err != nil {
+			return nil, err
+		}
+		newPub, err := newKP.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+		oc.SigningKeys.Add(newPub)
+		if retiring != "" {
+			if err := scheduleSkExpiry(ctx, retiring, time.Now().Add(p.overlap)); err != nil {
+				return nil, err
+			}
+			r.AddOK("generated operator signing key %q, retiring %q after %s", newPub, retiring, p.overlap)
+		} else {
+			r.AddOK("generated operator signing key %q, no prior signing key to retire", newPub)
+		}
+	}
+
+	token, err := oc.Encode(p.signerKP)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.StoreCtx().Store.StoreClaim([]byte(token)); err != nil {
+		return nil, err
+	}
+	r.AddOK("edited operator")
+	return r, nil
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nsc/cmd/store"
+	"github.com/spf13/cobra"
+)
+
+func createEditUserCmd() *cobra.Command {
+	var params EditUserParams
+	cmd := &cobra.Command{
+		Use:          "user",
+		Short:        "Edit a user",
+		Args:         MaxArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunAction(cmd, args, &params)
+		},
+	}
+	cmd.Flags().StringVarP(&params.name, "name", "n", "", "user name")
+	cmd.Flags().BoolVarP(&params.bearer, "bearer", "", false, "no connect challenge required for user")
+	cmd.Flags().StringSliceVarP(&params.allowPub, "allow-pub", "", nil, "publish permissions - comma separated list or option can be specified multiple times")
+	cmd.Flags().StringSliceVarP(&params.allowSub, "allow-sub", "", nil, "subscribe permissions - comma separated list or option can be specified multiple times")
+	params.AccountContextParams.BindFlags(cmd)
+	return cmd
+}
+
+func init() {
+	editCmd.AddCommand(createEditUserCmd())
+}
+
+// EditUserParams holds the flags understood by `nsc edit user`.
+type EditUserParams struct {
+	AccountContextParams
+	SignerParams
+	name     string
+	bearer   bool
+	allowPub []string
+	allowSub []string
+	claim    *jwt.UserClaims
+}
+
+func (p *EditUserParams) SetDefaults(ctx ActionCtx) error {
+	p.AccountContextParams.SetDefaults(ctx)
+	return nil
+}
+
+func (p *EditUserParams) PreInteractive(ctx ActionCtx) error {
+	return nil
+}
+
+func (p *EditUserParams) Load(ctx ActionCtx) error {
+	if err := p.AccountContextParams.Validate(ctx); err != nil {
+		return err
+	}
+	uc, err := ctx.StoreCtx().Store.ReadUserClaim(p.AccountContextParams.Name, p.name)
+	if err != nil {
+		return err
+	}
+	p.claim = uc
+	return nil
+}
+
+func (p *EditUserParams) PostInteractive(ctx ActionCtx) error {
+	return nil
+}
+
+func (p *EditUserParams) Validate(ctx ActionCtx) error {
+	ac, err := ctx.StoreCtx().Store.ReadAccountClaim(p.AccountContextParams.Name)
+	if err != nil {
+		return err
+	}
+	if p.bearer {
+		if ac.DisallowBearer {
+			return fmt.Errorf("account disallows bearer token")
+		}
+	}
+	if len(p.allowPub) > 0 || len(p.allowSub) > 0 {
+		perm := p.claim.Permissions
+		for _, s := range p.allowPub {
+			perm.Pub.Allow.Add(s)
+		}
+		for _, s := range p.allowSub {
+			perm.Sub.Allow.Add(s)
+		}
+		if err := requireExplicitAllowRules(ctx, ac.Subject, perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *EditUserParams) Run(ctx ActionCtx) (store.Status, error) {
+	r := store.NewDetailedReport(true)
+	uc := p.claim
+	if p.bearer {
+		uc.BearerToken = true
+	}
+	for _, s := range p.allowPub {
+		uc.Permissions.Pub.Allow.Add(s)
+	}
+	for _, s := range p.allowSub {
+		uc.Permissions.Sub.Allow.Add(s)
+	}
+
+	token, err := uc.Encode(p.signerKP)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.StoreCtx().Store.StoreClaim([]byte(token)); err != nil {
+		return nil, err
+	}
+	r.AddOK("edited user %q", uc.Name)
+	return r, nil
+}
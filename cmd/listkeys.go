@@ -0,0 +1,69 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func createListKeysCmd() *cobra.Command {
+	var accountName string
+	cmd := &cobra.Command{
+		Use:          "keys",
+		Short:        "List keys, including signing key rotation status",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := NewActionCtx(cmd, args)
+			if err != nil {
+				return err
+			}
+			if accountName == "" {
+				accountName = ctx.StoreCtx().Account.Name
+			}
+			ac, err := ctx.StoreCtx().Store.ReadAccountClaim(accountName)
+			if err != nil {
+				return err
+			}
+			f, err := loadSkRotationFile(ctx)
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			cmd.Printf("account %q (%s)\n", ac.Name, ac.Subject)
+			for _, k := range ac.SigningKeys {
+				cmd.Println(formatSkLine(k, skStatus(f, k, now), f.Schedules[k]))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&accountName, "account", "", "", "account name")
+	return cmd
+}
+
+func formatSkLine(pubKey string, status SkStatus, sched SkSchedule) string {
+	if status == SkActive {
+		return fmt.Sprintf("%s  %s", pubKey, status)
+	}
+	return fmt.Sprintf("%s  %s (not-after %s)", pubKey, status, sched.NotAfter.Format(time.RFC3339))
+}
+
+func init() {
+	listCmd.AddCommand(createListKeysCmd())
+}
@@ -0,0 +1,605 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/nats-io/jwt/v2"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// planFileName is the name of the plan catalog file kept alongside the
+// operator's other nsc data, one catalog per operator.
+const planFileName = "plans.yaml"
+
+// planTagPrefix is used to record which plan an account was last made to
+// conform to, e.g. "plan:pro".
+const planTagPrefix = "plan:"
+
+// Plan is a named bundle of account limits that can be applied to many
+// accounts at once, so operators don't have to repeat the same set of
+// --conns/--data/--js-* flags for every account on a given tier.
+//
+// Set records exactly which limit flags were given when the plan was
+// defined (add/edit plan), using the same flag names edit account uses.
+// Without it, a flag left at its Go zero value (e.g. an unset --conns)
+// would be indistinguishable from an explicit `--conns 0`, and applying
+// the plan would stomp limits the plan never mentioned.
+type Plan struct {
+	Name        string             `json:"name" yaml:"name"`
+	Description string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Limits      jwt.OperatorLimits `json:"limits" yaml:"limits"`
+	Set         []string           `json:"set,omitempty" yaml:"set,omitempty"`
+}
+
+// planCatalog is the on-disk representation of plans.yaml.
+type planCatalog struct {
+	Plans []Plan `json:"plans" yaml:"plans"`
+}
+
+func planCatalogPath(ctx ActionCtx) string {
+	return filepath.Join(ctx.StoreCtx().Store.Dir, planFileName)
+}
+
+func loadPlanCatalog(ctx ActionCtx) (*planCatalog, error) {
+	path := planCatalogPath(ctx)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &planCatalog{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c planCatalog
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("error parsing %q: %v", path, err)
+	}
+	return &c, nil
+}
+
+func (c *planCatalog) save(ctx ActionCtx) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(planCatalogPath(ctx), data, 0600)
+}
+
+func (c *planCatalog) find(name string) *Plan {
+	for i := range c.Plans {
+		if c.Plans[i].Name == name {
+			return &c.Plans[i]
+		}
+	}
+	return nil
+}
+
+func (c *planCatalog) put(p Plan) {
+	for i := range c.Plans {
+		if c.Plans[i].Name == p.Name {
+			c.Plans[i] = p
+			return
+		}
+	}
+	c.Plans = append(c.Plans, p)
+}
+
+func (c *planCatalog) remove(name string) bool {
+	for i := range c.Plans {
+		if c.Plans[i].Name == name {
+			c.Plans = append(c.Plans[:i], c.Plans[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// loadPlan fetches a single named plan, erroring clearly if it isn't in
+// the catalog - this is the lookup --apply-plan relies on.
+func loadPlan(ctx ActionCtx, name string) (*Plan, error) {
+	c, err := loadPlanCatalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p := c.find(name)
+	if p == nil {
+		return nil, fmt.Errorf("plan %q not found - run 'nsc list plans' to see available plans", name)
+	}
+	return p, nil
+}
+
+// applyPlanLimits sets only the limits the plan actually defines (per
+// plan.Set), the same way setting that corresponding set of
+// --conns/--data/--js-* flags on `edit account` would - it never touches
+// a limit the plan doesn't mention. JetStream is applied through the
+// same global-vs-tiered conflict checks `edit account` itself uses.
+func applyPlanLimits(ac *jwt.AccountClaims, plan *Plan) error {
+	settingTiered := hasFlag(plan.Set, "js-tier")
+	settingJs := hasAnyOf(plan.Set, jsFieldNames)
+
+	if settingTiered {
+		if err := checkJsTierConflict(ac); err != nil {
+			return err
+		}
+	} else if settingJs {
+		if err := checkJsGlobalConflict(ac); err != nil {
+			return err
+		}
+	}
+
+	if hasFlag(plan.Set, "conns") {
+		ac.Limits.Conn = plan.Limits.Conn
+	}
+	if hasFlag(plan.Set, "data") {
+		ac.Limits.Data = plan.Limits.Data
+	}
+	if hasFlag(plan.Set, "exports") {
+		ac.Limits.Exports = plan.Limits.Exports
+	}
+	if hasFlag(plan.Set, "imports") {
+		ac.Limits.Imports = plan.Limits.Imports
+	}
+	if hasFlag(plan.Set, "payload") {
+		ac.Limits.Payload = plan.Limits.Payload
+	}
+	if hasFlag(plan.Set, "subscriptions") {
+		ac.Limits.Subs = plan.Limits.Subs
+	}
+	if hasFlag(plan.Set, "leaf-conns") {
+		ac.Limits.LeafNodeConn = plan.Limits.LeafNodeConn
+	}
+
+	if settingTiered {
+		tierName := planTierName(plan)
+		if ac.Limits.JetStreamTieredLimits == nil {
+			ac.Limits.JetStreamTieredLimits = make(map[string]jwt.JetStreamLimits)
+		}
+		tl := ac.Limits.JetStreamTieredLimits[tierName]
+		mergeJsLimits(&tl, plan.Limits.JetStreamTieredLimits[tierName], plan.Set)
+		ac.Limits.JetStreamTieredLimits[tierName] = tl
+	} else if settingJs {
+		mergeJsLimits(&ac.Limits.JetStreamLimits, plan.Limits.JetStreamLimits, plan.Set)
+	}
+	return nil
+}
+
+// planTierName returns the single tier name a plan with js-tier in its
+// Set was built against - jsLimitFlags.apply only ever populates one
+// entry in JetStreamTieredLimits per plan.
+func planTierName(plan *Plan) string {
+	for name := range plan.Limits.JetStreamTieredLimits {
+		return name
+	}
+	return ""
+}
+
+// mergeJsLimits copies only the JetStreamLimits fields named in set from
+// src into dst, leaving the rest of dst untouched.
+func mergeJsLimits(dst *jwt.JetStreamLimits, src jwt.JetStreamLimits, set []string) {
+	if hasFlag(set, "js-streams") {
+		dst.Streams = src.Streams
+	}
+	if hasFlag(set, "js-consumer") {
+		dst.Consumer = src.Consumer
+	}
+	if hasFlag(set, "js-disk-storage") {
+		dst.DiskStorage = src.DiskStorage
+	}
+	if hasFlag(set, "js-mem-storage") {
+		dst.MemoryStorage = src.MemoryStorage
+	}
+	if hasFlag(set, "js-max-disk-stream") {
+		dst.DiskMaxStreamBytes = src.DiskMaxStreamBytes
+	}
+	if hasFlag(set, "js-max-mem-stream") {
+		dst.MemoryMaxStreamBytes = src.MemoryMaxStreamBytes
+	}
+	if hasFlag(set, "js-max-ack-pending") {
+		dst.MaxAckPending = src.MaxAckPending
+	}
+}
+
+func hasAnyOf(set []string, names []string) bool {
+	for _, n := range names {
+		if hasFlag(set, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// setPlanTag replaces any existing "plan:*" tag with the one for the
+// applied plan, so `nsc list accounts` can show the active tier.
+func setPlanTag(ac *jwt.AccountClaims, name string) {
+	var kept jwt.TagList
+	for _, t := range ac.Tags {
+		if !isPlanTag(t) {
+			kept = append(kept, t)
+		}
+	}
+	ac.Tags = kept
+	ac.Tags.Add(planTagPrefix + name)
+}
+
+func isPlanTag(tag string) bool {
+	return len(tag) > len(planTagPrefix) && tag[:len(planTagPrefix)] == planTagPrefix
+}
+
+func createAddPlanCmd() *cobra.Command {
+	var plan Plan
+	var limitFlags jsLimitFlags
+	cmd := &cobra.Command{
+		Use:          "plan",
+		Short:        "Add a plan to the operator's plan catalog",
+		Args:         cobra.NoArgs,
+		Example:      `nsc add plan --name pro --conns 1000 --js-streams 100`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := NewActionCtx(cmd, args)
+			if err != nil {
+				return err
+			}
+			if plan.Name == "" {
+				return fmt.Errorf("--name is required")
+			}
+			c, err := loadPlanCatalog(ctx)
+			if err != nil {
+				return err
+			}
+			if c.find(plan.Name) != nil {
+				return fmt.Errorf("plan %q already exists - use 'nsc edit plan' to change it", plan.Name)
+			}
+			if err := limitFlags.apply(cmd, &plan); err != nil {
+				return err
+			}
+			if err := plan.Limits.Validate(); err != nil {
+				return err
+			}
+			c.put(plan)
+			if err := c.save(ctx); err != nil {
+				return err
+			}
+			cmd.Printf("added plan %q\n", plan.Name)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&plan.Name, "name", "n", "", "plan name")
+	cmd.Flags().StringVarP(&plan.Description, "description", "", "", "plan description")
+	limitFlags.bindFlags(cmd)
+	return cmd
+}
+
+func createEditPlanCmd() *cobra.Command {
+	var name string
+	var description string
+	var limitFlags jsLimitFlags
+	cmd := &cobra.Command{
+		Use:          "plan",
+		Short:        "Edit a plan in the operator's plan catalog",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := NewActionCtx(cmd, args)
+			if err != nil {
+				return err
+			}
+			c, err := loadPlanCatalog(ctx)
+			if err != nil {
+				return err
+			}
+			plan := c.find(name)
+			if plan == nil {
+				return fmt.Errorf("plan %q not found", name)
+			}
+			if cmd.Flags().Changed("description") {
+				plan.Description = description
+			}
+			if err := limitFlags.apply(cmd, plan); err != nil {
+				return err
+			}
+			if err := plan.Limits.Validate(); err != nil {
+				return err
+			}
+			c.put(*plan)
+			if err := c.save(ctx); err != nil {
+				return err
+			}
+			cmd.Printf("edited plan %q\n", name)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&name, "name", "n", "", "plan name")
+	cmd.Flags().StringVarP(&description, "description", "", "", "plan description")
+	limitFlags.bindFlags(cmd)
+	return cmd
+}
+
+func createDeletePlanCmd() *cobra.Command {
+	var name string
+	cmd := &cobra.Command{
+		Use:          "plan",
+		Short:        "Delete a plan from the operator's plan catalog",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := NewActionCtx(cmd, args)
+			if err != nil {
+				return err
+			}
+			c, err := loadPlanCatalog(ctx)
+			if err != nil {
+				return err
+			}
+			if !c.remove(name) {
+				return fmt.Errorf("plan %q not found", name)
+			}
+			if err := c.save(ctx); err != nil {
+				return err
+			}
+			cmd.Printf("deleted plan %q\n", name)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&name, "name", "n", "", "plan name")
+	return cmd
+}
+
+func createListPlansCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "plans",
+		Short:        "List plans in the operator's plan catalog",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := NewActionCtx(cmd, args)
+			if err != nil {
+				return err
+			}
+			c, err := loadPlanCatalog(ctx)
+			if err != nil {
+				return err
+			}
+			names := make([]string, 0, len(c.Plans))
+			for _, p := range c.Plans {
+				names = append(names, p.Name)
+			}
+			sort.Strings(names)
+			for _, n := range names {
+				cmd.Println(n)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func createDescribePlanCmd() *cobra.Command {
+	var name string
+	cmd := &cobra.Command{
+		Use:          "plan",
+		Short:        "Describe a plan in the operator's plan catalog",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := NewActionCtx(cmd, args)
+			if err != nil {
+				return err
+			}
+			plan, err := loadPlan(ctx, name)
+			if err != nil {
+				return err
+			}
+			data, err := yaml.Marshal(plan)
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(data))
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&name, "name", "n", "", "plan name")
+	return cmd
+}
+
+// jsFieldNames are the flat/tiered JetStream limit flags shared by
+// `nsc edit account` and the plan catalog.
+var jsFieldNames = []string{
+	"js-streams", "js-consumer", "js-disk-storage", "js-mem-storage",
+	"js-max-disk-stream", "js-max-mem-stream", "js-max-ack-pending",
+}
+
+// jsLimitFlags mirrors the limit flags on `nsc edit account` so a plan can
+// be built from exactly the same options, just stored under a name instead
+// of applied once. Like edit account, it only ever touches a field the
+// operator actually passed - apply() uses cmd.Flags().Changed rather than
+// a zero-value check, so an explicit `--conns 0` is preserved.
+type jsLimitFlags struct {
+	conns         int64
+	data          string
+	exports       int64
+	imports       int64
+	payload       string
+	subscriptions int64
+	leafConns     int64
+
+	jsTier          int64
+	jsStreams       int64
+	jsConsumer      int64
+	jsDiskStorage   string
+	jsMemStorage    string
+	jsMaxDiskStream string
+	jsMaxMemStream  string
+	jsMaxAckPending int64
+}
+
+func (f *jsLimitFlags) bindFlags(cmd *cobra.Command) {
+	cmd.Flags().Int64VarP(&f.conns, "conns", "", 0, "maximum active connections (-1 is unlimited)")
+	cmd.Flags().StringVarP(&f.data, "data", "", "", "maximum data in bytes (-1 is unlimited)")
+	cmd.Flags().Int64VarP(&f.exports, "exports", "", 0, "maximum number of exports (-1 is unlimited)")
+	cmd.Flags().Int64VarP(&f.imports, "imports", "", 0, "maximum number of imports (-1 is unlimited)")
+	cmd.Flags().StringVarP(&f.payload, "payload", "", "", "maximum message payload in bytes (-1 is unlimited)")
+	cmd.Flags().Int64VarP(&f.subscriptions, "subscriptions", "", 0, "maximum number of subscriptions (-1 is unlimited)")
+	cmd.Flags().Int64VarP(&f.leafConns, "leaf-conns", "", 0, "maximum number of leafnode connections (-1 is unlimited)")
+	cmd.Flags().Int64VarP(&f.jsTier, "js-tier", "", 0, "store the js-* limits below as a tier instead of a global limit")
+	cmd.Flags().Int64VarP(&f.jsStreams, "js-streams", "", 0, "maximum number of streams (-1 is unlimited)")
+	cmd.Flags().Int64VarP(&f.jsConsumer, "js-consumer", "", 0, "maximum number of consumer (-1 is unlimited)")
+	cmd.Flags().StringVarP(&f.jsDiskStorage, "js-disk-storage", "", "", "maximum disk storage in bytes (-1 is unlimited)")
+	cmd.Flags().StringVarP(&f.jsMemStorage, "js-mem-storage", "", "", "maximum memory storage in bytes (-1 is unlimited)")
+	cmd.Flags().StringVarP(&f.jsMaxDiskStream, "js-max-disk-stream", "", "", "maximum disk a stream can use (-1 is unlimited)")
+	cmd.Flags().StringVarP(&f.jsMaxMemStream, "js-max-mem-stream", "", "", "maximum memory a stream can use (-1 is unlimited)")
+	cmd.Flags().Int64VarP(&f.jsMaxAckPending, "js-max-ack-pending", "", 0, "maximum ack pending for a consumer (-1 is unlimited)")
+}
+
+// apply records every flag the operator actually passed into plan.Set and
+// writes its value into plan.Limits, erroring if the plan mixes a global
+// js-* limit with --js-tier, the same conflict edit account refuses.
+func (f *jsLimitFlags) apply(cmd *cobra.Command, plan *Plan) error {
+	changed := func(name string) bool { return cmd.Flags().Changed(name) }
+	remember := func(name string) {
+		if !hasFlag(plan.Set, name) {
+			plan.Set = append(plan.Set, name)
+		}
+	}
+
+	l := &plan.Limits
+	if changed("conns") {
+		l.Conn = f.conns
+		remember("conns")
+	}
+	if changed("data") {
+		v, err := ParseDataSize(f.data)
+		if err != nil {
+			return err
+		}
+		l.Data = v
+		remember("data")
+	}
+	if changed("exports") {
+		l.Exports = f.exports
+		remember("exports")
+	}
+	if changed("imports") {
+		l.Imports = f.imports
+		remember("imports")
+	}
+	if changed("payload") {
+		v, err := ParseDataSize(f.payload)
+		if err != nil {
+			return err
+		}
+		l.Payload = v
+		remember("payload")
+	}
+	if changed("subscriptions") {
+		l.Subs = f.subscriptions
+		remember("subscriptions")
+	}
+	if changed("leaf-conns") {
+		l.LeafNodeConn = f.leafConns
+		remember("leaf-conns")
+	}
+
+	settingTiered := changed("js-tier")
+	settingJs := false
+	for _, n := range jsFieldNames {
+		if changed(n) {
+			settingJs = true
+			break
+		}
+	}
+	if settingTiered && settingJs && !l.JetStreamLimits.IsEmpty() {
+		return fmt.Errorf("cannot set a jetstream tier limit when the plan has a global limit")
+	}
+	if settingJs && len(l.JetStreamTieredLimits) > 0 && !settingTiered {
+		return fmt.Errorf("cannot set a jetstream global limit when the plan has tiered limits")
+	}
+
+	target := &l.JetStreamLimits
+	if settingTiered {
+		remember("js-tier")
+		tierName := fmt.Sprintf("R%d", f.jsTier)
+		for name := range l.JetStreamTieredLimits {
+			if name != tierName {
+				return fmt.Errorf("plan already has tier %q, a plan holds one jetstream tier", name)
+			}
+		}
+		if l.JetStreamTieredLimits == nil {
+			l.JetStreamTieredLimits = make(map[string]jwt.JetStreamLimits)
+		}
+		tl := l.JetStreamTieredLimits[tierName]
+		target = &tl
+		defer func() { l.JetStreamTieredLimits[tierName] = tl }()
+	}
+
+	if changed("js-streams") {
+		target.Streams = f.jsStreams
+		remember("js-streams")
+	}
+	if changed("js-consumer") {
+		target.Consumer = f.jsConsumer
+		remember("js-consumer")
+	}
+	if changed("js-disk-storage") {
+		v, err := ParseDataSize(f.jsDiskStorage)
+		if err != nil {
+			return err
+		}
+		target.DiskStorage = v
+		remember("js-disk-storage")
+	}
+	if changed("js-mem-storage") {
+		v, err := ParseDataSize(f.jsMemStorage)
+		if err != nil {
+			return err
+		}
+		target.MemoryStorage = v
+		remember("js-mem-storage")
+	}
+	if changed("js-max-disk-stream") {
+		v, err := ParseDataSize(f.jsMaxDiskStream)
+		if err != nil {
+			return err
+		}
+		target.DiskMaxStreamBytes = v
+		remember("js-max-disk-stream")
+	}
+	if changed("js-max-mem-stream") {
+		v, err := ParseDataSize(f.jsMaxMemStream)
+		if err != nil {
+			return err
+		}
+		target.MemoryMaxStreamBytes = v
+		remember("js-max-mem-stream")
+	}
+	if changed("js-max-ack-pending") {
+		target.MaxAckPending = f.jsMaxAckPending
+		remember("js-max-ack-pending")
+	}
+	return nil
+}
+
+func init() {
+	addCmd.AddCommand(createAddPlanCmd())
+	editCmd.AddCommand(createEditPlanCmd())
+	deleteCmd.AddCommand(createDeletePlanCmd())
+	listCmd.AddCommand(createListPlansCmd())
+	describeCmd.AddCommand(createDescribePlanCmd())
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AddEditDeletePlan(t *testing.T) {
+	ts := NewTestStore(t, "plans")
+	defer ts.Done(t)
+
+	_, _, err := ExecuteCmd(createAddPlanCmd(), "--name", "pro", "--conns", "1000", "--js-streams", "100")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(createAddPlanCmd(), "--name", "pro", "--conns", "1000")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `plan "pro" already exists`)
+
+	_, _, err = ExecuteCmd(createEditPlanCmd(), "--name", "pro", "--conns", "2000")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(createDeletePlanCmd(), "--name", "pro")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(createDeletePlanCmd(), "--name", "pro")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `plan "pro" not found`)
+}
+
+func Test_ApplyPlanOnEditAccount(t *testing.T) {
+	ts := NewTestStore(t, "plans")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, _, err := ExecuteCmd(createAddPlanCmd(), "--name", "pro", "--conns", "1000", "--js-streams", "100")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--apply-plan", "pro")
+	require.NoError(t, err)
+
+	ac, err := ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), ac.Limits.Conn)
+	require.Equal(t, int64(100), ac.Limits.Streams)
+	require.Contains(t, ac.Tags, "plan:pro")
+}
+
+func Test_ApplyPlanOnAddAccount(t *testing.T) {
+	ts := NewTestStore(t, "plans")
+	defer ts.Done(t)
+
+	_, _, err := ExecuteCmd(createAddPlanCmd(), "--name", "starter", "--conns", "10")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(createAddAccountCmd(), "--name", "B", "--apply-plan", "starter")
+	require.NoError(t, err)
+
+	ac, err := ts.Store.ReadAccountClaim("B")
+	require.NoError(t, err)
+	require.Equal(t, int64(10), ac.Limits.Conn)
+	require.Contains(t, ac.Tags, "plan:starter")
+}
+
+func Test_ApplyUnknownPlan(t *testing.T) {
+	ts := NewTestStore(t, "plans")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, _, err := ExecuteCmd(createEditAccount(), "--apply-plan", "does-not-exist")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `plan "does-not-exist" not found`)
+}
+
+func Test_ApplyTieredPlan(t *testing.T) {
+	ts := NewTestStore(t, "plans")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, _, err := ExecuteCmd(createAddPlanCmd(), "--name", "tiered", "--js-tier", "2", "--js-streams", "10")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--apply-plan", "tiered")
+	require.NoError(t, err)
+
+	ac, err := ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.Equal(t, int64(10), ac.Limits.JetStreamTieredLimits["R2"].Streams)
+	require.True(t, ac.Limits.JetStreamLimits.IsEmpty())
+}
+
+func Test_TieredPlanConflictsWithExistingGlobal(t *testing.T) {
+	ts := NewTestStore(t, "plans")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, _, err := ExecuteCmd(createEditAccount(), "--js-streams", "5")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(createAddPlanCmd(), "--name", "tiered", "--js-tier", "2", "--js-streams", "10")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--apply-plan", "tiered")
+	require.Error(t, err)
+	require.Equal(t, "cannot set a jetstream tier limit when a configuration has a global limit", err.Error())
+}
+
+func Test_PlanMixingGlobalAndTierIsRejected(t *testing.T) {
+	ts := NewTestStore(t, "plans")
+	defer ts.Done(t)
+
+	_, _, err := ExecuteCmd(createAddPlanCmd(), "--name", "bad", "--js-streams", "5")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(createEditPlanCmd(), "--name", "bad", "--js-tier", "2", "--js-consumer", "1")
+	require.Error(t, err)
+}
+
+func Test_ApplyPlanDoesNotClobberUnsetLimits(t *testing.T) {
+	ts := NewTestStore(t, "plans")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, _, err := ExecuteCmd(createEditAccount(), "--exports", "42")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(createAddPlanCmd(), "--name", "conns-only", "--conns", "10")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--apply-plan", "conns-only")
+	require.NoError(t, err)
+
+	ac, err := ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.Equal(t, int64(10), ac.Limits.Conn)
+	require.Equal(t, int64(42), ac.Limits.Exports)
+}
+
+func Test_ApplyPlanPreservesExplicitZero(t *testing.T) {
+	ts := NewTestStore(t, "plans")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, _, err := ExecuteCmd(createEditAccount(), "--conns", "10")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(createAddPlanCmd(), "--name", "zeroed", "--conns", "0")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--apply-plan", "zeroed")
+	require.NoError(t, err)
+
+	ac, err := ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), ac.Limits.Conn)
+}
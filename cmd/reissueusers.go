@@ -0,0 +1,135 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/nats-io/nkeys"
+	"github.com/nats-io/nsc/cmd/store"
+	"github.com/spf13/cobra"
+)
+
+// reissueCmd is the parent for `nsc reissue <resource>`, following the
+// same add/edit/delete/describe/list grouping convention as the rest of
+// the command tree.
+var reissueCmd = &cobra.Command{
+	Use:   "reissue",
+	Short: "Re-sign resources",
+}
+
+func init() {
+	GetRootCmd().AddCommand(reissueCmd)
+}
+
+// createReissueUsersCmd walks every user signed by a retiring signing key
+// and re-signs them with the account's active signing key, so operators
+// can finish a --rotate-sk before the retiring key's overlap window
+// closes.
+func createReissueUsersCmd() *cobra.Command {
+	var params ReissueUsersParams
+	cmd := &cobra.Command{
+		Use:          "users",
+		Short:        "Re-sign users still issued by a retiring signing key",
+		Args:         cobra.NoArgs,
+		Example:      `nsc reissue users --account A`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunAction(cmd, args, &params)
+		},
+	}
+	params.AccountContextParams.BindFlags(cmd)
+	return cmd
+}
+
+func init() {
+	reissueCmd.AddCommand(createReissueUsersCmd())
+}
+
+// ReissueUsersParams holds the flags understood by `nsc reissue users`.
+type ReissueUsersParams struct {
+	AccountContextParams
+}
+
+func (p *ReissueUsersParams) SetDefaults(ctx ActionCtx) error {
+	p.AccountContextParams.SetDefaults(ctx)
+	return nil
+}
+
+func (p *ReissueUsersParams) PreInteractive(ctx ActionCtx) error {
+	return p.AccountContextParams.Edit(ctx)
+}
+
+func (p *ReissueUsersParams) Load(ctx ActionCtx) error {
+	return p.AccountContextParams.Validate(ctx)
+}
+
+func (p *ReissueUsersParams) PostInteractive(ctx ActionCtx) error {
+	return nil
+}
+
+func (p *ReissueUsersParams) Validate(ctx ActionCtx) error {
+	return nil
+}
+
+func (p *ReissueUsersParams) Run(ctx ActionCtx) (store.Status, error) {
+	r := store.NewDetailedReport(true)
+	ac, err := ctx.StoreCtx().Store.ReadAccountClaim(p.AccountContextParams.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := loadSkRotationFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	users, err := ctx.StoreCtx().Store.ListEntries(store.Users, ac.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	var signer nkeys.KeyPair
+	for _, u := range users {
+		uc, err := ctx.StoreCtx().Store.ReadUserClaim(ac.Subject, u)
+		if err != nil {
+			return nil, err
+		}
+		if skStatus(f, uc.Issuer, now) == SkActive {
+			continue
+		}
+		if signer == nil {
+			activeSk, err := resolveActiveSk(f, ac.SigningKeys, now)
+			if err != nil {
+				return nil, err
+			}
+			signer, err = ctx.StoreCtx().KeyStore.GetKeyPair(activeSk)
+			if err != nil {
+				return nil, err
+			}
+		}
+		token, err := uc.Encode(signer)
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.StoreCtx().Store.StoreClaim([]byte(token)); err != nil {
+			return nil, err
+		}
+		r.AddOK("reissued user %q", u)
+	}
+	return r, nil
+}
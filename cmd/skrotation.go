@@ -0,0 +1,169 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// skRotationFileName is the nsc-local metadata sidecar that tracks signing
+// key retirement schedules. The JWT claim format has no TTL field for
+// signing keys, so --rotate-sk/--expire-sk record it here instead, keyed
+// by the signing key's public key.
+const skRotationFileName = "sk-rotation.yaml"
+
+// SkSchedule is one signing key's recorded retirement time.
+type SkSchedule struct {
+	NotAfter time.Time `json:"not_after" yaml:"not_after"`
+}
+
+type skRotationFile struct {
+	Schedules map[string]SkSchedule `json:"schedules" yaml:"schedules"`
+}
+
+func skRotationPath(ctx ActionCtx) string {
+	return filepath.Join(ctx.StoreCtx().Store.Dir, skRotationFileName)
+}
+
+func loadSkRotationFile(ctx ActionCtx) (*skRotationFile, error) {
+	path := skRotationPath(ctx)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &skRotationFile{Schedules: map[string]SkSchedule{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f skRotationFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("error parsing %q: %v", path, err)
+	}
+	if f.Schedules == nil {
+		f.Schedules = map[string]SkSchedule{}
+	}
+	return &f, nil
+}
+
+func (f *skRotationFile) save(ctx ActionCtx) error {
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(skRotationPath(ctx), data, 0600)
+}
+
+// scheduleSkExpiry records that pubKey should be treated as retiring
+// after notAfter - used by --rotate-sk and --expire-sk alike.
+func scheduleSkExpiry(ctx ActionCtx, pubKey string, notAfter time.Time) error {
+	f, err := loadSkRotationFile(ctx)
+	if err != nil {
+		return err
+	}
+	f.Schedules[pubKey] = SkSchedule{NotAfter: notAfter}
+	return f.save(ctx)
+}
+
+// SkStatus is the lifecycle state `nsc list keys` reports for a signing
+// key: "active" (no schedule), "retiring" (a schedule that is still in
+// the future), or "expired" (schedule has passed).
+type SkStatus string
+
+const (
+	SkActive   SkStatus = "active"
+	SkRetiring SkStatus = "retiring"
+	SkExpired  SkStatus = "expired"
+)
+
+func skStatus(f *skRotationFile, pubKey string, now time.Time) SkStatus {
+	sched, ok := f.Schedules[pubKey]
+	if !ok {
+		return SkActive
+	}
+	if now.After(sched.NotAfter) {
+		return SkExpired
+	}
+	return SkRetiring
+}
+
+// resolveSkToRetire picks the signing key --rotate-sk should schedule for
+// retirement. Signing keys live in a different keyspace than the key
+// used to authorize the edit (an operator/account identity key, or an
+// explicit -K), so the key to retire must come from keys itself: with
+// exactly one entry that's the obvious choice, with none there's nothing
+// to retire yet, and with more than one rotateFrom must say which.
+func resolveSkToRetire(keys []string, rotateFrom string) (string, error) {
+	if rotateFrom != "" {
+		for _, k := range keys {
+			if k == rotateFrom {
+				return k, nil
+			}
+		}
+		return "", fmt.Errorf("%q is not one of the current signing keys", rotateFrom)
+	}
+	switch len(keys) {
+	case 0:
+		return "", nil
+	case 1:
+		return keys[0], nil
+	default:
+		return "", fmt.Errorf("more than one signing key is active, specify which to retire with --rotate-from")
+	}
+}
+
+// resolveActiveSk picks the signing key reissue should sign new user JWTs
+// with - the one entry in keys that isn't retiring or expired. Like
+// resolveSkToRetire, it refuses to guess when that's ambiguous.
+func resolveActiveSk(f *skRotationFile, keys []string, now time.Time) (string, error) {
+	var active []string
+	for _, k := range keys {
+		if skStatus(f, k, now) == SkActive {
+			active = append(active, k)
+		}
+	}
+	switch len(active) {
+	case 0:
+		return "", fmt.Errorf("account has no active signing key to reissue users with")
+	case 1:
+		return active[0], nil
+	default:
+		return "", fmt.Errorf("account has more than one active signing key, retire all but one before reissuing users")
+	}
+}
+
+// requireAnActiveSk refuses the edit if every signing key in keys is past
+// its scheduled retirement, since the account would otherwise be left
+// impossible to administer under its own signing keys.
+func requireAnActiveSk(ctx ActionCtx, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	f, err := loadSkRotationFile(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, k := range keys {
+		if skStatus(f, k, now) != SkExpired {
+			return nil
+		}
+	}
+	return fmt.Errorf("all signing keys are past their retirement time, remove them with --rm-sk before adding a new one")
+}
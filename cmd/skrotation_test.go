@@ -0,0 +1,182 @@
+/*
+ * Copyright 2018-2023 The NATS Authors
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EditAccountRotateSk(t *testing.T) {
+	ts := NewTestStore(t, "edit account")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+
+	_, _, err := ExecuteCmd(createEditAccount(), "--rotate-sk")
+	require.Error(t, err)
+	require.Equal(t, "--rotate-sk requires --overlap", err.Error())
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--rotate-sk", "--overlap", "72h")
+	require.NoError(t, err)
+
+	ac, err := ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.Len(t, ac.SigningKeys, 1)
+}
+
+func Test_EditAccountRotateSkRetiresExistingKey(t *testing.T) {
+	ts := NewTestStore(t, "edit account")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, _, err := ExecuteCmd(createEditAccount(), "--sk", "generate")
+	require.NoError(t, err)
+
+	ac, err := ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.Len(t, ac.SigningKeys, 1)
+	original := ac.SigningKeys[0]
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--rotate-sk", "--overlap", "72h")
+	require.NoError(t, err)
+
+	ac, err = ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.Len(t, ac.SigningKeys, 2)
+	require.Contains(t, ac.SigningKeys, original)
+
+	stdout, _, err := ExecuteCmd(createListKeysCmd(), "--account", "A")
+	require.NoError(t, err)
+	require.Contains(t, stdout, original+"  retiring")
+}
+
+func Test_EditAccountRotateSkAmbiguousRequiresRotateFrom(t *testing.T) {
+	ts := NewTestStore(t, "edit account")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, _, err := ExecuteCmd(createEditAccount(), "--sk", "generate", "--sk", "generate")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--rotate-sk", "--overlap", "72h")
+	require.Error(t, err)
+
+	ac, err := ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--rotate-sk", "--overlap", "72h", "--rotate-from", ac.SigningKeys[0])
+	require.NoError(t, err)
+}
+
+func Test_EditAccountExpireSk(t *testing.T) {
+	ts := NewTestStore(t, "edit account")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, pk, _ := CreateAccountKey(t)
+
+	_, _, err := ExecuteCmd(createEditAccount(), "--expire-sk", pk)
+	require.Error(t, err)
+	require.Equal(t, "--expire-sk requires --at", err.Error())
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--sk", pk, "--expire-sk", pk, "--at", "2050-01-01")
+	require.NoError(t, err)
+}
+
+func Test_EditAccountAllSkExpiredBlocksEdit(t *testing.T) {
+	ts := NewTestStore(t, "edit account")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, pk, _ := CreateAccountKey(t)
+
+	_, _, err := ExecuteCmd(createEditAccount(), "--sk", pk, "--expire-sk", pk, "--at", "2000-01-01")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--sk", pk)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "past their retirement time")
+}
+
+func Test_EditOperatorRotateSkRetiresExistingKey(t *testing.T) {
+	ts := NewTestStore(t, "edit operator")
+	defer ts.Done(t)
+
+	_, _, err := ExecuteCmd(createEditOperatorCmd(), "--sk", "generate")
+	require.NoError(t, err)
+
+	oc, err := ts.Store.ReadOperatorClaim()
+	require.NoError(t, err)
+	require.Len(t, oc.SigningKeys, 1)
+	original := oc.SigningKeys[0]
+
+	_, _, err = ExecuteCmd(createEditOperatorCmd(), "--rotate-sk", "--overlap", "72h")
+	require.NoError(t, err)
+
+	oc, err = ts.Store.ReadOperatorClaim()
+	require.NoError(t, err)
+	require.Len(t, oc.SigningKeys, 2)
+	require.Contains(t, oc.SigningKeys, original)
+
+	ctx, err := NewActionCtx(createEditOperatorCmd(), nil)
+	require.NoError(t, err)
+	f, err := loadSkRotationFile(ctx)
+	require.NoError(t, err)
+	require.Contains(t, f.Schedules, original, "the operator's pre-existing signing key should be the one scheduled for retirement")
+}
+
+func Test_ReissueUsersAfterRotation(t *testing.T) {
+	ts := NewTestStore(t, "edit account")
+	defer ts.Done(t)
+
+	ts.AddAccount(t, "A")
+	_, _, err := ExecuteCmd(createEditAccount(), "--sk", "generate")
+	require.NoError(t, err)
+
+	ac, err := ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	sk := ac.SigningKeys[0]
+
+	_, _, err = ExecuteCmd(CreateAddUserCmd(), "--name", "U", "--account", "A", "-K", sk)
+	require.NoError(t, err)
+
+	uc, err := ts.Store.ReadUserClaim("A", "U")
+	require.NoError(t, err)
+	require.Equal(t, sk, uc.Issuer)
+
+	_, _, err = ExecuteCmd(createEditAccount(), "--rotate-sk", "--overlap", "0s")
+	require.NoError(t, err)
+
+	ac, err = ts.Store.ReadAccountClaim("A")
+	require.NoError(t, err)
+	require.Len(t, ac.SigningKeys, 2)
+	var newSk string
+	for _, k := range ac.SigningKeys {
+		if k != sk {
+			newSk = k
+		}
+	}
+	require.NotEmpty(t, newSk)
+
+	_, _, err = ExecuteCmd(createReissueUsersCmd(), "--account", "A")
+	require.NoError(t, err)
+
+	uc, err = ts.Store.ReadUserClaim("A", "U")
+	require.NoError(t, err)
+	require.Equal(t, newSk, uc.Issuer, "reissue should re-sign with the account's new active signing key")
+}